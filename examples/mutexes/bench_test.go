@@ -0,0 +1,64 @@
+package main
+
+import (
+	"flag"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/makhov/gobyexample/internal/benchutil"
+)
+
+var (
+	benchReaders = flag.Int("reads", 100, "number of reader goroutines")
+	benchWriters = flag.Int("writes", 10, "number of writer goroutines")
+	benchKeys    = flag.Int("keys", 5, "size of the key space")
+	benchDur     = flag.Duration("dur", time.Second, "duration to run the benchmark for")
+)
+
+// mutexStore is the mutex-guarded equivalent of statefulstore.Store,
+// used only to give BenchmarkMutexStore something directly comparable to
+// BenchmarkChannelStore in examples/stateful-goroutines.
+type mutexStore struct {
+	mu    sync.RWMutex
+	state map[int]int
+}
+
+func newMutexStore() *mutexStore {
+	return &mutexStore{state: make(map[int]int)}
+}
+
+func (s *mutexStore) Get(key int) (int, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	val, ok := s.state[key]
+	return val, ok
+}
+
+func (s *mutexStore) Set(key, val int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state[key] = val
+}
+
+// BenchmarkMutexStore drives a mutexStore with -reads reader and -writes
+// writer goroutines hammering random keys in [0, -keys) for -dur, and
+// reports readOps/sec, writeOps/sec and p50/p99 per-request latency. Run
+// alongside BenchmarkChannelStore in examples/stateful-goroutines to
+// compare the mutex-based and channel-based approaches under identical
+// parameters.
+func BenchmarkMutexStore(b *testing.B) {
+	store := newMutexStore()
+
+	benchutil.Run(b, *benchReaders, *benchWriters, *benchKeys, *benchDur,
+		func(key int) time.Duration {
+			start := time.Now()
+			store.Get(key)
+			return time.Since(start)
+		},
+		func(key, val int) time.Duration {
+			start := time.Now()
+			store.Set(key, val)
+			return time.Since(start)
+		})
+}
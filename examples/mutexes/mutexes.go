@@ -0,0 +1,62 @@
+// В предыдущих примерах мы видели, как управлять простым
+// состоянием с помощью атомарных операций. Для более
+// сложного состояния можно использовать мьютекс (`Mutex`)
+// для безопасного доступа к данным из нескольких горутин.
+
+package main
+
+import (
+    "fmt"
+    "sync"
+    "sync/atomic"
+)
+
+// Container содержит карту счётчиков; поскольку к ней будут
+// обращаться несколько горутин одновременно, доступ
+// защищается мьютексом `sync.Mutex`.
+type Container struct {
+    mu       sync.Mutex
+    counters map[string]int
+}
+
+// Перед чтением или изменением карты `counters` сначала
+// нужно захватить мьютекс `mu`. Отложенный `Unlock`
+// гарантирует, что блокировка будет снята до выхода из
+// функции.
+func (c *Container) inc(name string) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    c.counters[name]++
+}
+
+func main() {
+    var ops int64 = 0
+
+    c := Container{
+        counters: map[string]int{"a": 0, "b": 0},
+    }
+
+    var wg sync.WaitGroup
+
+    // Эта функция увеличивает именованный счётчик в цикле
+    // ровно `n` раз, дожидаясь разблокировки мьютекса при
+    // каждом обращении.
+    doIncrement := func(name string, n int) {
+        for i := 0; i < n; i++ {
+            c.inc(name)
+            atomic.AddInt64(&ops, 1)
+        }
+        wg.Done()
+    }
+
+    // Запускаем несколько горутин, одновременно
+    // обращающихся к одному и тому же `Container`.
+    wg.Add(3)
+    go doIncrement("a", 10000)
+    go doIncrement("a", 10000)
+    go doIncrement("b", 10000)
+
+    wg.Wait()
+    fmt.Println(c.counters)
+    fmt.Println("ops:", atomic.LoadInt64(&ops))
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"testing"
+	"time"
+
+	"github.com/makhov/gobyexample/internal/benchutil"
+	"github.com/makhov/gobyexample/pkg/statefulstore"
+)
+
+var (
+	benchReaders = flag.Int("reads", 100, "number of reader goroutines")
+	benchWriters = flag.Int("writes", 10, "number of writer goroutines")
+	benchKeys    = flag.Int("keys", 5, "size of the key space")
+	benchDur     = flag.Duration("dur", time.Second, "duration to run the benchmark for")
+)
+
+// BenchmarkChannelStore drives a statefulstore.Store with -reads reader
+// and -writes writer goroutines hammering random keys in [0, -keys) for
+// -dur, and reports readOps/sec, writeOps/sec and p50/p99 per-request
+// latency. Run alongside BenchmarkMutexStore in examples/mutexes to
+// compare the channel-based and mutex-based approaches under identical
+// parameters.
+func BenchmarkChannelStore(b *testing.B) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	store := statefulstore.NewStore[int, int](ctx)
+
+	benchutil.Run(b, *benchReaders, *benchWriters, *benchKeys, *benchDur,
+		func(key int) time.Duration {
+			start := time.Now()
+			store.Get(key)
+			return time.Since(start)
+		},
+		func(key, val int) time.Duration {
+			start := time.Now()
+			store.Set(key, val)
+			return time.Since(start)
+		})
+}
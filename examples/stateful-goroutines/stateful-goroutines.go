@@ -10,77 +10,39 @@
 package main
 
 import (
+    "context"
     "fmt"
     "math/rand"
     "sync/atomic"
     "time"
-)
 
-// В этом примере наше состояние принадлежит одной
-// горутине. Это гарантирует, что данные никогда не будут
-// скомпрометированы конкурентным доступом. Для того,
-// чтобы читать или изменять состояние, другие горутины
-// будут отправлять сообшения владеющей горутине и
-// получать соответствующие ответы. `Структуры` `readOp`
-// и `writeOp` инкапсулируют эти запросы и способы ответа
-// владеющей горутины.
-type readOp struct {
-    key  int
-    resp chan int
-}
-type writeOp struct {
-    key  int
-    val  int
-    resp chan bool
-}
+    "github.com/makhov/gobyexample/pkg/statefulstore"
+)
 
 func main() {
 
-    // Как и прежде мы будем считать, сколько мы произвели операций
-    var ops int64 = 0
+    // Как и прежде мы будем считать, сколько операций чтения и
+    // записи мы произвели, но теперь раздельно.
+    var readOps uint64
+    var writeOps uint64
+    var txOps uint64
 
-    // Каналя `reads` и `writes` будут использованы другими
-    // горутинами для чтения и записи запросов соответственно.
-    reads := make(chan *readOp)
-    writes := make(chan *writeOp)
-
-    // Здесь горутина, которой принадлежит `state`, который
-    // Here is the goroutine that owns the `state`, which
-    // is a map as in the previous example but now private
-    // to the stateful goroutine. This goroutine repeatedly
-    // selects on the `reads` and `writes` channels,
-    // responding to requests as they arrive. A response
-    // is executed by first performing the requested
-    // operation and then sending a value on the response
-    // channel `resp` to indicate success (and the desired
-    // value in the case of `reads`).
-    go func() {
-        var state = make(map[int]int)
-        for {
-            select {
-            case read := <-reads:
-                read.resp <- state[read.key]
-            case write := <-writes:
-                state[write.key] = write.val
-                write.resp <- true
-            }
-        }
-    }()
+    // Владеющая горутина теперь живёт внутри pkg/statefulstore:
+    // Store[K, V] запускает её за нас и останавливает, когда ctx
+    // отменяется.
+    ctx, cancel := context.WithCancel(context.Background())
+    defer cancel()
+    store := statefulstore.NewStore[int, int](ctx)
 
     // This starts 100 goroutines to issue reads to the
-    // state-owning goroutine via the `reads` channel.
-    // Each read requires constructing a `readOp`, sending
-    // it over the `reads` channel, and the receiving the
-    // result over the provided `resp` channel.
+    // store via Get. Each read is a single call; the Store
+    // takes care of routing it to the owning goroutine and
+    // waiting for the answer.
     for r := 0; r < 100; r++ {
         go func() {
             for {
-                read := &readOp{
-                    key:  rand.Intn(5),
-                    resp: make(chan int)}
-                reads <- read
-                <-read.resp
-                atomic.AddInt64(&ops, 1)
+                store.Get(rand.Intn(5))
+                atomic.AddUint64(&readOps, 1)
             }
         }()
     }
@@ -90,21 +52,34 @@ func main() {
     for w := 0; w < 10; w++ {
         go func() {
             for {
-                write := &writeOp{
-                    key:  rand.Intn(5),
-                    val:  rand.Intn(100),
-                    resp: make(chan bool)}
-                writes <- write
-                <-write.resp
-                atomic.AddInt64(&ops, 1)
+                store.Set(rand.Intn(5), rand.Intn(100))
+                atomic.AddUint64(&writeOps, 1)
             }
         }()
     }
 
+    // This goroutine demonstrates Tx: it increments key `5` by
+    // reading its current value and writing vals[5]+1 back in the
+    // same turn of the owner's select loop, so the read and the
+    // write can never be split by another goroutine's write landing
+    // in between, which plain Get then Set cannot guarantee.
+    go func() {
+        for {
+            store.Tx([]int{5}, func(vals map[int]int) map[int]int {
+                return map[int]int{5: vals[5] + 1}
+            })
+            atomic.AddUint64(&txOps, 1)
+        }
+    }()
+
     // Let the goroutines work for a second.
     time.Sleep(time.Second)
 
-    // Finally, capture and report the `ops` count.
-    opsFinal := atomic.LoadInt64(&ops)
-    fmt.Println("ops:", opsFinal)
+    // Finally, capture and report the op counts.
+    readOpsFinal := atomic.LoadUint64(&readOps)
+    writeOpsFinal := atomic.LoadUint64(&writeOps)
+    txOpsFinal := atomic.LoadUint64(&txOps)
+    fmt.Println("readOps:", readOpsFinal)
+    fmt.Println("writeOps:", writeOpsFinal)
+    fmt.Println("txOps:", txOpsFinal)
 }
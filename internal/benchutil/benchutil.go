@@ -0,0 +1,91 @@
+// Package benchutil holds the load-generation harness shared by the
+// stateful-goroutines and mutexes benchmarks, so both drive their store
+// under the exact same shape of load and report metrics the same way.
+package benchutil
+
+import (
+	"math/rand"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// Run drives readOp with `readers` goroutines and writeOp with `writers`
+// goroutines, both hammering random keys in [0, keys) for dur, and
+// reports readOps/sec, writeOps/sec and p50/p99 per-request latency on
+// b via b.ReportMetric.
+func Run(b *testing.B, readers, writers, keys int, dur time.Duration, readOp func(key int) time.Duration, writeOp func(key, val int) time.Duration) {
+	var readOps, writeOps int64
+	var mu sync.Mutex
+	var readLatencies, writeLatencies []time.Duration
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+
+	b.ResetTimer()
+
+	for r := 0; r < readers; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				d := readOp(rand.Intn(keys))
+				atomic.AddInt64(&readOps, 1)
+				mu.Lock()
+				readLatencies = append(readLatencies, d)
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				d := writeOp(rand.Intn(keys), rand.Intn(100))
+				atomic.AddInt64(&writeOps, 1)
+				mu.Lock()
+				writeLatencies = append(writeLatencies, d)
+				mu.Unlock()
+			}
+		}()
+	}
+
+	time.Sleep(dur)
+	close(stop)
+	wg.Wait()
+	b.StopTimer()
+
+	elapsed := dur.Seconds()
+	b.ReportMetric(float64(readOps)/elapsed, "readOps/sec")
+	b.ReportMetric(float64(writeOps)/elapsed, "writeOps/sec")
+	b.ReportMetric(float64(percentile(readLatencies, 0.50)), "read-p50-ns")
+	b.ReportMetric(float64(percentile(readLatencies, 0.99)), "read-p99-ns")
+	b.ReportMetric(float64(percentile(writeLatencies, 0.50)), "write-p50-ns")
+	b.ReportMetric(float64(percentile(writeLatencies, 0.99)), "write-p99-ns")
+}
+
+// percentile returns the p-th percentile (0 <= p <= 1) latency, in
+// nanoseconds, from an unsorted slice of durations.
+func percentile(d []time.Duration, p float64) int64 {
+	if len(d) == 0 {
+		return 0
+	}
+	sorted := append([]time.Duration(nil), d...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	idx := int(p * float64(len(sorted)-1))
+	return sorted[idx].Nanoseconds()
+}
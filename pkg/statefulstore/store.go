@@ -0,0 +1,240 @@
+// Package statefulstore generalizes the "one goroutine owns the data"
+// pattern from the stateful-goroutines example into a reusable, generic
+// store. A single owning goroutine holds a map[K]V and serves reads and
+// writes sent to it over channels, so the map itself is never touched
+// concurrently. This is useful any time plain mutexes would be
+// error-prone to get right, e.g. when several related channels or
+// multiple locks would otherwise have to be kept in sync.
+package statefulstore
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrClosed is returned by a Store's methods once its context has been
+// cancelled, so callers can tell "the store is gone" apart from a
+// perfectly normal zero value or missing key.
+var ErrClosed = errors.New("statefulstore: store closed")
+
+type readOp[K comparable, V any] struct {
+	key  K
+	resp chan readResult[V]
+}
+
+type readResult[V any] struct {
+	val V
+	ok  bool
+}
+
+type writeOp[K comparable, V any] struct {
+	key  K
+	val  V
+	resp chan bool
+}
+
+type deleteOp[K comparable] struct {
+	key  K
+	resp chan bool
+}
+
+type lenOp struct {
+	resp chan int
+}
+
+type snapshotOp[K comparable, V any] struct {
+	resp chan map[K]V
+}
+
+type txOp[K comparable, V any] struct {
+	reads  []K
+	writes func(map[K]V) map[K]V
+	resp   chan txResult[K, V]
+}
+
+type txResult[K comparable, V any] struct {
+	vals map[K]V
+}
+
+// Store owns a map[K]V from a single goroutine, started by NewStore, and
+// serves Get/Set/Delete/Len/Snapshot/Tx requests to it over channels.
+type Store[K comparable, V any] struct {
+	reads     chan readOp[K, V]
+	writes    chan writeOp[K, V]
+	deletes   chan deleteOp[K]
+	lens      chan lenOp
+	snapshots chan snapshotOp[K, V]
+	txs       chan txOp[K, V]
+	done      <-chan struct{}
+}
+
+// NewStore starts the owning goroutine and returns the Store backed by
+// it. Cancelling ctx stops the goroutine; any request already in flight
+// is abandoned rather than left to block forever, and is reported back
+// to its caller as ErrClosed (see Get, Set, Delete, Len and Snapshot).
+func NewStore[K comparable, V any](ctx context.Context) *Store[K, V] {
+	s := &Store[K, V]{
+		reads:     make(chan readOp[K, V]),
+		writes:    make(chan writeOp[K, V]),
+		deletes:   make(chan deleteOp[K]),
+		lens:      make(chan lenOp),
+		snapshots: make(chan snapshotOp[K, V]),
+		txs:       make(chan txOp[K, V]),
+		done:      ctx.Done(),
+	}
+
+	go func() {
+		state := make(map[K]V)
+		for {
+			select {
+			case read := <-s.reads:
+				val, ok := state[read.key]
+				read.resp <- readResult[V]{val: val, ok: ok}
+			case write := <-s.writes:
+				state[write.key] = write.val
+				write.resp <- true
+			case del := <-s.deletes:
+				_, ok := state[del.key]
+				delete(state, del.key)
+				del.resp <- ok
+			case l := <-s.lens:
+				l.resp <- len(state)
+			case snap := <-s.snapshots:
+				cp := make(map[K]V, len(state))
+				for k, v := range state {
+					cp[k] = v
+				}
+				snap.resp <- cp
+			case tx := <-s.txs:
+				vals := make(map[K]V, len(tx.reads))
+				for _, k := range tx.reads {
+					vals[k] = state[k]
+				}
+				for k, v := range tx.writes(vals) {
+					state[k] = v
+				}
+				tx.resp <- txResult[K, V]{vals: vals}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return s
+}
+
+// Get returns the value stored under k and whether it was present. Once
+// the store's context has been cancelled, Get returns the zero value,
+// false and ErrClosed instead of blocking.
+func (s *Store[K, V]) Get(k K) (V, bool, error) {
+	req := readOp[K, V]{key: k, resp: make(chan readResult[V])}
+	select {
+	case s.reads <- req:
+	case <-s.done:
+		var zero V
+		return zero, false, ErrClosed
+	}
+	select {
+	case res := <-req.resp:
+		return res.val, res.ok, nil
+	case <-s.done:
+		var zero V
+		return zero, false, ErrClosed
+	}
+}
+
+// Set stores v under k. It returns ErrClosed without doing anything once
+// the store's context has been cancelled.
+func (s *Store[K, V]) Set(k K, v V) error {
+	req := writeOp[K, V]{key: k, val: v, resp: make(chan bool)}
+	select {
+	case s.writes <- req:
+	case <-s.done:
+		return ErrClosed
+	}
+	select {
+	case <-req.resp:
+		return nil
+	case <-s.done:
+		return ErrClosed
+	}
+}
+
+// Delete removes k from the store, reporting whether it was present. It
+// returns false and ErrClosed without doing anything once the store's
+// context has been cancelled.
+func (s *Store[K, V]) Delete(k K) (bool, error) {
+	req := deleteOp[K]{key: k, resp: make(chan bool)}
+	select {
+	case s.deletes <- req:
+	case <-s.done:
+		return false, ErrClosed
+	}
+	select {
+	case ok := <-req.resp:
+		return ok, nil
+	case <-s.done:
+		return false, ErrClosed
+	}
+}
+
+// Len returns the number of keys currently in the store, or 0 and
+// ErrClosed once the store's context has been cancelled.
+func (s *Store[K, V]) Len() (int, error) {
+	req := lenOp{resp: make(chan int)}
+	select {
+	case s.lens <- req:
+	case <-s.done:
+		return 0, ErrClosed
+	}
+	select {
+	case n := <-req.resp:
+		return n, nil
+	case <-s.done:
+		return 0, ErrClosed
+	}
+}
+
+// Snapshot returns a copy of the store's current contents, or nil and
+// ErrClosed once the store's context has been cancelled.
+func (s *Store[K, V]) Snapshot() (map[K]V, error) {
+	req := snapshotOp[K, V]{resp: make(chan map[K]V)}
+	select {
+	case s.snapshots <- req:
+	case <-s.done:
+		return nil, ErrClosed
+	}
+	select {
+	case m := <-req.resp:
+		return m, nil
+	case <-s.done:
+		return nil, ErrClosed
+	}
+}
+
+// Tx atomically reads the given keys, passes the result to writes, and
+// applies whatever map writes returns, all in a single turn of the
+// owning goroutine's select loop: writes sees the state exactly as the
+// reads observed it, and no other Get, Set, Delete or Tx is interleaved
+// in between. It returns the values read (before writes ran) and
+// ErrClosed once the store's context has been cancelled.
+//
+// Because writes is given the values Tx itself just read, it can make
+// the new values depend on them — e.g. write key B based on what was
+// read for key A, or write key A computed from the value read for A
+// (a compare-and-swap-like increment) — something Get/Set alone cannot
+// do without a race between the read and the write.
+func (s *Store[K, V]) Tx(reads []K, writes func(map[K]V) map[K]V) (map[K]V, error) {
+	req := txOp[K, V]{reads: reads, writes: writes, resp: make(chan txResult[K, V])}
+	select {
+	case s.txs <- req:
+	case <-s.done:
+		return nil, ErrClosed
+	}
+	select {
+	case res := <-req.resp:
+		return res.vals, nil
+	case <-s.done:
+		return nil, ErrClosed
+	}
+}
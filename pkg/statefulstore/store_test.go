@@ -0,0 +1,114 @@
+package statefulstore
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestStoreGetSetDelete(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s := NewStore[string, int](ctx)
+
+	if _, ok, err := s.Get("a"); ok || err != nil {
+		t.Fatalf("Get on empty store = (_, %v, %v), want (_, false, nil)", ok, err)
+	}
+
+	if err := s.Set("a", 1); err != nil {
+		t.Fatalf("Set returned %v, want nil", err)
+	}
+
+	if val, ok, err := s.Get("a"); !ok || err != nil || val != 1 {
+		t.Fatalf("Get(%q) = (%v, %v, %v), want (1, true, nil)", "a", val, ok, err)
+	}
+
+	if ok, err := s.Delete("a"); !ok || err != nil {
+		t.Fatalf("Delete(%q) = (%v, %v), want (true, nil)", "a", ok, err)
+	}
+
+	if _, ok, err := s.Get("a"); ok || err != nil {
+		t.Fatalf("Get after Delete = (_, %v, %v), want (_, false, nil)", ok, err)
+	}
+
+	if ok, err := s.Delete("a"); ok || err != nil {
+		t.Fatalf("Delete of missing key = (%v, %v), want (false, nil)", ok, err)
+	}
+}
+
+func TestStoreLenAndSnapshot(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s := NewStore[string, int](ctx)
+
+	s.Set("a", 1)
+	s.Set("b", 2)
+
+	if n, err := s.Len(); n != 2 || err != nil {
+		t.Fatalf("Len() = (%d, %v), want (2, nil)", n, err)
+	}
+
+	snap, err := s.Snapshot()
+	if err != nil {
+		t.Fatalf("Snapshot returned error %v, want nil", err)
+	}
+	if snap["a"] != 1 || snap["b"] != 2 || len(snap) != 2 {
+		t.Fatalf("Snapshot() = %v, want map[a:1 b:2]", snap)
+	}
+
+	snap["a"] = 99
+	if val, _, _ := s.Get("a"); val != 1 {
+		t.Fatalf("mutating snapshot affected store: Get(%q) = %d, want 1", "a", val)
+	}
+}
+
+func TestStoreTxComputesWritesFromReads(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s := NewStore[string, int](ctx)
+	s.Set("counter", 5)
+
+	vals, err := s.Tx([]string{"counter"}, func(vals map[string]int) map[string]int {
+		return map[string]int{"counter": vals["counter"] + 1}
+	})
+	if err != nil {
+		t.Fatalf("Tx returned error %v, want nil", err)
+	}
+	if vals["counter"] != 5 {
+		t.Fatalf("Tx returned read values %v, want counter=5 (pre-write)", vals)
+	}
+
+	if val, _, _ := s.Get("counter"); val != 6 {
+		t.Fatalf("Get(%q) after Tx = %d, want 6", "counter", val)
+	}
+}
+
+func TestStoreClosedReturnsErrClosed(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := NewStore[string, int](ctx)
+	cancel()
+
+	if _, ok, err := s.Get("a"); ok || !errors.Is(err, ErrClosed) {
+		t.Fatalf("Get on closed store = (_, %v, %v), want (_, false, ErrClosed)", ok, err)
+	}
+
+	if err := s.Set("a", 1); !errors.Is(err, ErrClosed) {
+		t.Fatalf("Set on closed store = %v, want ErrClosed", err)
+	}
+
+	if _, err := s.Delete("a"); !errors.Is(err, ErrClosed) {
+		t.Fatalf("Delete on closed store = %v, want ErrClosed", err)
+	}
+
+	if _, err := s.Len(); !errors.Is(err, ErrClosed) {
+		t.Fatalf("Len on closed store = %v, want ErrClosed", err)
+	}
+
+	if _, err := s.Snapshot(); !errors.Is(err, ErrClosed) {
+		t.Fatalf("Snapshot on closed store = %v, want ErrClosed", err)
+	}
+
+	if vals, err := s.Tx([]string{"a"}, func(vals map[string]int) map[string]int { return vals }); !errors.Is(err, ErrClosed) || vals != nil {
+		t.Fatalf("Tx on closed store = (%v, %v), want (nil, ErrClosed)", vals, err)
+	}
+}